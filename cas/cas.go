@@ -0,0 +1,78 @@
+// Package cas is a content-addressed store for fetched page bodies. Each
+// body is written once under its SHA-256 hash, so identical bodies
+// served from different URLs (404 pages, mirrored assets) are only
+// stored once, and every fetch is recorded as a line in manifest.tsv.
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store writes bodies under dir/<first2-of-sha256>/<sha256> and appends
+// one manifest.tsv line per fetch. It is safe for concurrent use.
+type Store struct {
+	dir string
+
+	mu       sync.Mutex
+	manifest *os.File
+}
+
+// Open creates dir if necessary and opens (creating if necessary)
+// dir/manifest.tsv for appending.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cas: create %s: %w", dir, err)
+	}
+	manifest, err := os.OpenFile(filepath.Join(dir, "manifest.tsv"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cas: open manifest: %w", err)
+	}
+	return &Store{dir: dir, manifest: manifest}, nil
+}
+
+// Close closes the manifest file.
+func (s *Store) Close() error {
+	return s.manifest.Close()
+}
+
+// Put writes body under its content hash (skipping the write if that
+// hash is already on disk) and appends a manifest line recording that
+// rawURL served it. It returns the body's hex SHA-256 digest.
+func (s *Store) Put(rawURL, contentType string, status int, body []byte) (string, error) {
+	sum := sha256.Sum256(body)
+	digest := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writeBody(digest, body); err != nil {
+		return digest, err
+	}
+	_, err := fmt.Fprintf(s.manifest, "%s\t%s\t%s\t%d\n", rawURL, digest, contentType, status)
+	return digest, err
+}
+
+// writeBody writes body under its content digest, skipping the write if
+// that digest is already on disk. The caller must hold s.mu: checking
+// for existence and writing the file must be atomic with respect to
+// other Put calls, or a concurrent fetcher could observe a half-written
+// file and wrongly treat it as already cached.
+func (s *Store) writeBody(digest string, body []byte) error {
+	subdir := filepath.Join(s.dir, digest[:2])
+	path := filepath.Join(subdir, digest)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(subdir, 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, body, 0644)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package cas
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPutDedupsIdenticalBodies(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer s.Close()
+
+	body := []byte("same content")
+	d1, err := s.Put("http://example.com/a", "text/plain", 200, body)
+	if err != nil {
+		t.Fatalf("Put a: %s", err)
+	}
+	d2, err := s.Put("http://example.com/b", "text/plain", 200, body)
+	if err != nil {
+		t.Fatalf("Put b: %s", err)
+	}
+	if d1 != d2 {
+		t.Fatalf("identical bodies got different digests: %s vs %s", d1, d2)
+	}
+
+	path := filepath.Join(dir, d1[:2], d1)
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading stored body: %s", err)
+	}
+	if string(stored) != string(body) {
+		t.Errorf("stored body = %q, want %q", stored, body)
+	}
+
+	manifestPath := filepath.Join(dir, "manifest.tsv")
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		t.Fatalf("opening manifest: %s", err)
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 {
+		t.Fatalf("manifest has %d lines, want 2 (one per fetch, even when the body is deduped)", len(lines))
+	}
+	if !strings.Contains(lines[0], "http://example.com/a") || !strings.Contains(lines[1], "http://example.com/b") {
+		t.Errorf("manifest lines = %v, want entries for both URLs", lines)
+	}
+}
@@ -0,0 +1,115 @@
+// Package extract implements pluggable outbound-link discovery for the
+// different content types the crawler fetches: HTML pages, CSS
+// stylesheets, and XML sitemaps (including sitemap index files).
+package extract
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Extractor pulls candidate outbound link strings (relative or
+// absolute, as written in the source) out of a fetched body. Resolving
+// them against the page URL and filtering by scope/robots happens
+// downstream; an Extractor just finds raw references.
+type Extractor interface {
+	Extract(body []byte) []string
+}
+
+// ForContentType returns the Extractor appropriate for a response's
+// Content-Type header, or nil if none applies.
+func ForContentType(contentType string) Extractor {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "html"):
+		return HTML{}
+	case strings.Contains(ct, "css"):
+		return CSS{}
+	case strings.Contains(ct, "xml"):
+		return Sitemap{}
+	default:
+		return nil
+	}
+}
+
+// htmlLinkAttrs maps each tag we scan to the attribute holding its URL.
+var htmlLinkAttrs = map[string]string{
+	"a":      "href",
+	"link":   "href",
+	"img":    "src",
+	"script": "src",
+	"iframe": "src",
+	"area":   "href",
+	"form":   "action",
+}
+
+// HTML extracts links from the usual elements that reference other
+// resources: a, link, img, script, iframe, area, and form[action].
+type HTML struct{}
+
+func (HTML) Extract(body []byte) []string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	var links []string
+	for tag, attr := range htmlLinkAttrs {
+		doc.Find(tag + "[" + attr + "]").Each(func(_ int, s *goquery.Selection) {
+			if v, ok := s.Attr(attr); ok && v != "" {
+				links = append(links, v)
+			}
+		})
+	}
+	return links
+}
+
+// cssURLPattern matches url(...) references and @import statements in a
+// stylesheet.
+var cssURLPattern = regexp.MustCompile(`url\(\s*["']?([^'"\)]+)["']?\s*\)|@import\s+["']([^"']+)["']`)
+
+// CSS extracts stylesheet references: url(...) and @import.
+type CSS struct{}
+
+func (CSS) Extract(body []byte) []string {
+	var links []string
+	for _, m := range cssURLPattern.FindAllStringSubmatch(string(body), -1) {
+		switch {
+		case m[1] != "":
+			links = append(links, m[1])
+		case m[2] != "":
+			links = append(links, m[2])
+		}
+	}
+	return links
+}
+
+// sitemapURLSet covers a plain sitemap.xml file (<urlset><url><loc>).
+type sitemapURLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Locs    []string `xml:"url>loc"`
+}
+
+// sitemapIndex covers a sitemap index file (<sitemapindex><sitemap><loc>).
+type sitemapIndex struct {
+	XMLName xml.Name `xml:"sitemapindex"`
+	Locs    []string `xml:"sitemap>loc"`
+}
+
+// Sitemap extracts <loc> entries from an XML sitemap or sitemap index.
+type Sitemap struct{}
+
+func (Sitemap) Extract(body []byte) []string {
+	var set sitemapURLSet
+	if err := xml.Unmarshal(body, &set); err == nil && len(set.Locs) > 0 {
+		return set.Locs
+	}
+	var idx sitemapIndex
+	if err := xml.Unmarshal(body, &idx); err == nil && len(idx.Locs) > 0 {
+		return idx.Locs
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        Extractor
+	}{
+		{"text/html; charset=utf-8", HTML{}},
+		{"text/css", CSS{}},
+		{"application/xml", Sitemap{}},
+		{"application/json", nil},
+	}
+	for _, tc := range tests {
+		if got := ForContentType(tc.contentType); got != tc.want {
+			t.Errorf("ForContentType(%q) = %#v, want %#v", tc.contentType, got, tc.want)
+		}
+	}
+}
+
+func TestHTMLExtract(t *testing.T) {
+	body := []byte(`<html><body>
+		<a href="/page1">link</a>
+		<link href="/style.css">
+		<img src="/logo.png">
+		<form action="/submit"></form>
+	</body></html>`)
+
+	got := HTML{}.Extract(body)
+	want := map[string]bool{"/page1": true, "/style.css": true, "/logo.png": true, "/submit": true}
+	if len(got) != len(want) {
+		t.Fatalf("Extract() = %v, want links for %v", got, want)
+	}
+	for _, link := range got {
+		if !want[link] {
+			t.Errorf("unexpected link %q", link)
+		}
+	}
+}
+
+func TestCSSExtract(t *testing.T) {
+	body := []byte(`
+		@import "reset.css";
+		.a { background: url(images/bg.png); }
+		.b { background: url('images/other.png'); }
+	`)
+
+	got := CSS{}.Extract(body)
+	want := []string{"reset.css", "images/bg.png", "images/other.png"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CSS{}.Extract() = %v, want %v", got, want)
+	}
+}
+
+func TestSitemapExtractURLSet(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+	<urlset><url><loc>http://example.com/a</loc></url><url><loc>http://example.com/b</loc></url></urlset>`)
+
+	got := Sitemap{}.Extract(body)
+	want := []string{"http://example.com/a", "http://example.com/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sitemap{}.Extract() = %v, want %v", got, want)
+	}
+}
+
+func TestSitemapExtractIndex(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+	<sitemapindex><sitemap><loc>http://example.com/sitemap1.xml</loc></sitemap></sitemapindex>`)
+
+	got := Sitemap{}.Extract(body)
+	want := []string{"http://example.com/sitemap1.xml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sitemap{}.Extract() = %v, want %v", got, want)
+	}
+}
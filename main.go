@@ -1,135 +1,124 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"flag"
 	"fmt"
-	"hash/fnv"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/Rishith-S/web-crawler-go/cas"
+	"github.com/Rishith-S/web-crawler-go/extract"
+	"github.com/Rishith-S/web-crawler-go/robots"
+	"github.com/Rishith-S/web-crawler-go/store"
+	"github.com/Rishith-S/web-crawler-go/warc"
+	"golang.org/x/time/rate"
 )
 
-var host string = "https://www.sjsu.edu/"
+const userAgent = "Golang_Custom_Bot/1.0"
 
-var disabledLinks = []string{}
+// defaultHostRate is the fallback per-host request rate used when a
+// host's robots.txt sets no Crawl-delay.
+const defaultHostRate = 2 * time.Second
 
-var client = &http.Client{
-	CheckRedirect: func(req *http.Request, via []*http.Request) error {
-		if len(via) >= 5 {
-			return fmt.Errorf("stopped after 5 redirects")
-		}
-		return nil
-	},
-	Timeout: 10 * time.Second,
-}
-
-type Element struct {
-}
-
-type Queue struct {
-	totalQueued int
-	number      int
-	elements    []string
-	mu          sync.Mutex
-}
+var concurrency = flag.Int("c", 10, "number of concurrent fetch workers")
+var maxDepth = flag.Int("depth", 3, "maximum link depth to follow from each seed")
+var outdir = flag.String("outdir", "", "if set, store fetched bodies content-addressed under this directory with a dedup manifest")
 
-type CrawledSet struct {
-	data   map[uint64]bool
-	number int
-	mu     sync.Mutex
-}
-
-func (q *Queue) enqueue(url string) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-	q.elements = append(q.elements, url)
-	q.totalQueued++
-	q.number++
-}
-
-func (q *Queue) dequeue() string {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-	url := q.elements[0]
-	q.elements = q.elements[1:]
-	q.number--
-	return url
-}
+// seeds holds the parsed, normalized seed URLs given on the command
+// line. A discovered link is only queued if it falls in scope under one
+// of these seeds, as decided by inScope.
+var seeds []*url.URL
 
-func (q *Queue) size() int {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-	return q.number
-}
-
-func (c *CrawledSet) add(url string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.data[hashUrl(url)] = true
-	c.number++
+// inScope reports whether u falls under one of the seeds: same host, and
+// its path is either exactly the seed's path or a "/"-bounded
+// descendant of it, so a seed of http://example.com does not also match
+// http://example.com.evil.com or http://example.com-other.
+func inScope(u *url.URL) bool {
+	for _, seed := range seeds {
+		if !strings.EqualFold(u.Host, seed.Host) || u.Scheme != seed.Scheme {
+			continue
+		}
+		seedPath := strings.TrimSuffix(seed.Path, "/")
+		path := u.Path
+		if path == seedPath {
+			return true
+		}
+		if strings.HasPrefix(path, seedPath+"/") {
+			return true
+		}
+	}
+	return false
 }
 
-func hashUrl(url string) uint64 {
-	h := fnv.New64a()
-	h.Write([]byte(url))
-	return h.Sum64()
+// cleanURL normalizes u in place: fragments are stripped, the host is
+// lowercased, and an explicit default port (80 for http, 443 for https)
+// is dropped, so that e.g. "http://x/a" and "http://x/a#top" hash to the
+// same CrawledSet entry.
+func cleanURL(u *url.URL) *url.URL {
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port != "" {
+		if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = strings.TrimSuffix(u.Host, ":"+port)
+		}
+	}
+	return u
 }
 
-func (c *CrawledSet) contains(url string) bool {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return c.data[hashUrl(url)]
+// normalizeURL resolves ref against base, handling relative paths like
+// "../foo", and normalizes the result via cleanURL.
+func normalizeURL(base *url.URL, ref string) (*url.URL, error) {
+	parsedRef, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	return cleanURL(base.ResolveReference(parsedRef)), nil
 }
 
-func (c *CrawledSet) size() int {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return c.number
+// encodeQueueItem/decodeQueueItem pack a queued URL with the depth at
+// which it was discovered, so the durable, string-keyed store.Queue can
+// carry depth information across a restart.
+func encodeQueueItem(u *url.URL, depth int) string {
+	return strconv.Itoa(depth) + "\t" + u.String()
 }
 
-func robotsTxtFetcher() {
-	client := &http.Client{
-		Timeout: 8 * time.Second,
+func decodeQueueItem(raw string) (*url.URL, int, error) {
+	depthStr, rawURL, ok := strings.Cut(raw, "\t")
+	if !ok {
+		return nil, 0, fmt.Errorf("malformed queue item %q", raw)
 	}
-	req, err := http.NewRequest("GET", "https://sjsu.edu/robots.txt", nil)
+	depth, err := strconv.Atoi(depthStr)
 	if err != nil {
-		log.Printf("Error making GET request: %s", err)
-		return
+		return nil, 0, fmt.Errorf("malformed queue item depth %q: %w", raw, err)
 	}
-	req.Header.Set("User-Agent", "Golang_Custom_Bot/1.0")
-	resp, err := client.Do(req)
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		log.Printf("Error making GET request: %s", err)
-		return
-	}
-	defer resp.Body.Close()
-	robotsTxt, err := io.ReadAll(resp.Body)
-	stringReader := strings.NewReader(string(robotsTxt))
-	scanner := bufio.NewScanner(stringReader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "Disallow:") {
-			fields := strings.Fields(line)
-			if len(fields) >= 2 {
-				disabledLinks = append(disabledLinks, fields[1])
-			}
-		}
+		return nil, 0, err
 	}
+	return u, depth, nil
 }
 
-func isAllowed(url string) bool {
-	for _, disallowed := range disabledLinks {
-		if strings.Contains(url, disallowed) {
-			return false
-		}
-	}
-	return true
+// client does not auto-follow redirects: fetchOnce follows them by hand
+// so each hop's Location header can be surfaced as an outlink before
+// it's followed.
+var client = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Timeout: 10 * time.Second,
 }
 
 func printError(err error, message string) {
@@ -153,78 +142,316 @@ func writeToFile(title string, currUrl string) {
 	}
 }
 
-func fetcher(url string, c chan *goquery.Document) {
-	for i := range 5 {
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			printError(err, "Error at line 112")
-			continue
+var hostLimiters sync.Map // host -> *rate.Limiter
+
+// limiterFor returns the rate.Limiter governing requests to rawURL's
+// host, creating one (seeded from the host's robots.txt Crawl-delay, or
+// defaultHostRate if it sets none) the first time the host is seen.
+func limiterFor(rawURL string, robotsEngine *robots.Robots) *rate.Limiter {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+	}
+	if v, ok := hostLimiters.Load(host); ok {
+		return v.(*rate.Limiter)
+	}
+	interval := robotsEngine.Delay(host)
+	if interval == robots.NoDelay {
+		interval = defaultHostRate
+	}
+	limiter := rate.NewLimiter(rate.Every(interval), 1)
+	actual, _ := hostLimiters.LoadOrStore(host, limiter)
+	return actual.(*rate.Limiter)
+}
+
+// redirectHop is one hop of a redirect chain: the Location header value
+// as written by the server, paired with the URL it was served from (the
+// base it must be resolved against, since a relative Location is
+// relative to its own hop, not the original request URL).
+type redirectHop struct {
+	base     *url.URL
+	location string
+}
+
+// fetchResult is a successfully fetched page: its final URL (after any
+// redirects), its Content-Type, its raw body, and every redirect hop
+// that led to it, in order.
+type fetchResult struct {
+	finalURL    *url.URL
+	contentType string
+	body        []byte
+	redirects   []redirectHop
+}
+
+// fetchWithRetry tries rawURL up to 5 times with backoff, records a WARC
+// response/request pair and (if casStore is non-nil) a content-addressed
+// copy for the attempt that succeeds, and returns the result (or nil if
+// every attempt failed). It aborts early if ctx is canceled.
+func fetchWithRetry(ctx context.Context, rawURL string, warcWriter *warc.Writer, casStore *cas.Store) *fetchResult {
+	for i := 0; i < 5; i++ {
+		if ctx.Err() != nil {
+			return nil
 		}
-		req.Header.Set("User-Agent", "Golang_Custom_Bot/1.0")
 		if i > 0 {
 			time.Sleep(time.Duration(2*i) * time.Second)
 		}
+		if result, ok := fetchOnce(ctx, rawURL, warcWriter, casStore); ok {
+			return result
+		}
+	}
+	return nil
+}
+
+// fetchOnce performs a single fetch attempt of at most 5 requests,
+// manually following up to 4 redirect hops so each hop's Location header
+// can be recorded as an outlink (via the returned redirects) before the
+// next hop is fetched.
+func fetchOnce(ctx context.Context, rawURL string, warcWriter *warc.Writer, casStore *cas.Store) (*fetchResult, bool) {
+	var redirects []redirectHop
+	current := rawURL
+
+	for hop := 0; hop < 5; hop++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", current, nil)
+		if err != nil {
+			printError(err, "Error at line 112")
+			return nil, false
+		}
+		req.Header.Set("User-Agent", userAgent)
+
 		resp, err := client.Do(req)
 		if err != nil {
 			printError(err, "Error at line 174")
-			continue
+			return nil, false
 		}
-		if resp.StatusCode == http.StatusOK {
-			doc, err := goquery.NewDocumentFromReader(resp.Body)
+
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			location := resp.Header.Get("Location")
 			resp.Body.Close()
+			if location == "" {
+				return nil, false
+			}
+
+			base, err := url.Parse(current)
 			if err != nil {
-				log.Fatal(err)
+				return nil, false
 			}
-			c <- doc
-			return
-		} else {
+			redirects = append(redirects, redirectHop{base: base, location: location})
+
+			next, err := normalizeURL(base, location)
+			if err != nil {
+				return nil, false
+			}
+			current = next.String()
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
 			log.Printf("Error making GET request: %s", resp.Status)
 			resp.Body.Close()
+			return nil, false
 		}
-	}
-	c <- nil
-}
 
-func parser(doc *goquery.Document, queue *Queue, currUrl string, crawledSet *CrawledSet) {
-
-	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if exists {
-			if strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") || strings.HasPrefix(href, "mailto:") {
-				return
-			}
+		var rawBody bytes.Buffer
+		_, err = io.Copy(&rawBody, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			printError(err, "Error reading response body\t")
+			return nil, false
+		}
 
-			if strings.HasPrefix(href, "/") {
-				href = strings.TrimSuffix(host, "/") + href
-			} else if !strings.HasPrefix(href, "http") {
-				return
+		writeWarcRecord(warcWriter, req, resp, rawBody.Bytes())
+		contentType := resp.Header.Get("Content-Type")
+		if casStore != nil {
+			if _, err := casStore.Put(current, contentType, resp.StatusCode, rawBody.Bytes()); err != nil {
+				printError(err, "Error writing content-addressed body\t")
 			}
+		}
 
-			if strings.HasPrefix(href, host) && isAllowed(href) && !crawledSet.contains(href) {
-				queue.enqueue(href)
-			}
+		finalURL, err := url.Parse(current)
+		if err != nil {
+			return nil, false
 		}
-	})
+		return &fetchResult{finalURL: finalURL, contentType: contentType, body: rawBody.Bytes(), redirects: redirects}, true
+	}
+
+	return nil, false
+}
+
+// writeWarcRecord archives a successful fetch as a WARC response record
+// followed by a request record referencing it via WARC-Concurrent-To.
+func writeWarcRecord(warcWriter *warc.Writer, req *http.Request, resp *http.Response, body []byte) {
+	statusLine := fmt.Sprintf("HTTP/%d.%d %s", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
+	recordID, err := warcWriter.WriteResponse(req.URL.String(), statusLine, resp.Header, body)
+	if err != nil {
+		printError(err, "Error writing WARC response record\t")
+		return
+	}
+	reqBytes, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		printError(err, "Error dumping request for WARC record\t")
+		return
+	}
+	if err := warcWriter.WriteRequest(req.URL.String(), recordID, reqBytes); err != nil {
+		printError(err, "Error writing WARC request record\t")
+	}
+}
+
+// discoverLink resolves rawLink against base and, if it's in scope,
+// within the depth limit, and robots-allowed, enqueues it at
+// currDepth+1 (unless it's already been crawled).
+func discoverLink(rawLink string, base *url.URL, currDepth int, queue *store.Queue, crawledSet *store.CrawledSet, robotsEngine *robots.Robots) {
+	if strings.HasPrefix(rawLink, "#") || strings.HasPrefix(rawLink, "javascript:") || strings.HasPrefix(rawLink, "mailto:") {
+		return
+	}
+
+	resolved, err := normalizeURL(base, rawLink)
+	if err != nil {
+		return
+	}
+	if currDepth+1 > *maxDepth || !inScope(resolved) {
+		return
+	}
 
-	title := doc.Find("title").Text()
+	target := resolved.String()
+	if robotsEngine.Allowed(userAgent, target) && !crawledSet.Contains(target) {
+		queue.Enqueue(encodeQueueItem(resolved, currDepth+1))
+	}
+}
 
-	writeToFile(title, currUrl)
+// processPage extracts outbound links using the extractor registered for
+// contentType, if any, and queues the in-scope, allowed ones. For HTML
+// pages it also writes the page title to result.txt as an optional
+// side-channel, matching the crawler's original output.
+func processPage(body []byte, contentType string, currURL *url.URL, currDepth int, queue *store.Queue, crawledSet *store.CrawledSet, robotsEngine *robots.Robots) {
+	if extractor := extract.ForContentType(contentType); extractor != nil {
+		for _, link := range extractor.Extract(body) {
+			discoverLink(link, currURL, currDepth, queue, crawledSet, robotsEngine)
+		}
+	}
 
+	if strings.Contains(strings.ToLower(contentType), "html") {
+		if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body)); err == nil {
+			writeToFile(doc.Find("title").Text(), currURL.String())
+		}
+	}
 }
 
 var wg sync.WaitGroup
 
+// inFlight counts URLs that have been dequeued but not yet finished
+// processing (fetch + parse), including any new links that fetch may
+// still enqueue. The crawl is done once it's zero and the queue is empty.
+var inFlight atomic.Int64
+
+// worker pulls encoded queue items from jobs until it's closed,
+// respecting each host's rate limiter before every fetch.
+func worker(ctx context.Context, jobs <-chan string, queue *store.Queue, crawled *store.CrawledSet, robotsEngine *robots.Robots, warcWriter *warc.Writer, casStore *cas.Store) {
+	defer wg.Done()
+	for raw := range jobs {
+		u, depth, err := decodeQueueItem(raw)
+		if err != nil {
+			printError(err, "Error decoding queue item\t")
+			inFlight.Add(-1)
+			continue
+		}
+		target := u.String()
+		if crawled.Contains(target) {
+			inFlight.Add(-1)
+			continue
+		}
+		if err := limiterFor(target, robotsEngine).Wait(ctx); err != nil {
+			// Canceled while waiting on the rate limiter: raw was already
+			// removed from the durable queue by the feed loop, so put it
+			// back rather than losing it.
+			inFlight.Add(-1)
+			if err := queue.Enqueue(raw); err != nil {
+				printError(err, "Error re-enqueueing queue item after rate-limiter cancellation\t")
+			}
+			continue
+		}
+		result := fetchWithRetry(ctx, target, warcWriter, casStore)
+		// Only mark the URL crawled once fetching it is actually done
+		// (succeeded, or exhausted its retries without being canceled),
+		// so a crash or Ctrl-C mid-fetch leaves it eligible to be
+		// retried on restart instead of being skipped as already-crawled.
+		if result != nil || ctx.Err() == nil {
+			if err := crawled.Add(target); err != nil {
+				printError(err, "Error marking URL crawled\t")
+			}
+		} else if err := queue.Enqueue(raw); err != nil {
+			printError(err, "Error re-enqueueing queue item after fetch cancellation\t")
+		}
+		if result != nil {
+			final := result.finalURL.String()
+			for _, redirect := range result.redirects {
+				// Skip the hop that resolves to the page we just fetched
+				// (the common case: this is its only Location), so it
+				// isn't re-enqueued and refetched as if it were a new
+				// outlink.
+				if resolved, err := normalizeURL(redirect.base, redirect.location); err == nil && resolved.String() == final {
+					continue
+				}
+				discoverLink(redirect.location, redirect.base, depth, queue, crawled, robotsEngine)
+			}
+			processPage(result.body, result.contentType, result.finalURL, depth, queue, crawled, robotsEngine)
+		}
+		inFlight.Add(-1)
+	}
+}
+
 func main() {
-	queue := Queue{totalQueued: 0, number: 0, elements: make([]string, 0)}
-	robotsTxtFetcher()
-	crawled := CrawledSet{data: make(map[uint64]bool)}
+	flag.Parse()
+	if len(flag.Args()) == 0 {
+		log.Fatal("usage: crawl [-c N] [-depth N] <seed-url> [seed-url...]")
+	}
+	for _, arg := range flag.Args() {
+		u, err := url.Parse(arg)
+		if err != nil {
+			log.Fatalf("invalid seed URL %q: %s", arg, err)
+		}
+		seeds = append(seeds, cleanURL(u))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	crawlStore, err := store.Open("crawler.db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer crawlStore.Close()
+
+	warcWriter, err := warc.NewWriter("crawl.warc.gz")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer warcWriter.Close()
+
+	var casStore *cas.Store
+	if *outdir != "" {
+		casStore, err = cas.Open(*outdir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer casStore.Close()
+	}
 
-	crawled.add(host)
-	c := make(chan *goquery.Document)
-	go fetcher(host, c)
-	content := <-c
-	if content != nil {
-		parser(content, &queue, host, &crawled)
+	queue, err := store.NewQueue(crawlStore)
+	if err != nil {
+		log.Fatal(err)
+	}
+	crawled := store.NewCrawledSet(crawlStore)
+	robotsEngine := robots.New()
+
+	if queue.Size() == 0 && crawled.Size() == 0 {
+		for _, seed := range seeds {
+			for _, sitemap := range robotsEngine.Sitemaps(seed.String()) {
+				if u, err := url.Parse(sitemap); err == nil {
+					queue.Enqueue(encodeQueueItem(cleanURL(u), 0))
+				}
+			}
+			queue.Enqueue(encodeQueueItem(seed, 0))
+		}
 	}
 
 	ticker := time.NewTicker(1 * time.Second)
@@ -237,25 +464,52 @@ func main() {
 			case <-done:
 				return
 			case t := <-ticker.C:
-				crawlerStats.update(&crawled, &queue, t)
+				crawlerStats.update(crawled, queue, t)
 			}
 		}
 	}()
 
-	for queue.size() > 0 && crawled.size() < 500 {
-		url := queue.dequeue()
-		crawled.add(url)
-		fetchChan := make(chan *goquery.Document)
-		go fetcher(url, fetchChan)
-		content := <-fetchChan
-		if content != nil {
-			wg.Add(1)
-			go func(doc *goquery.Document, url string) {
-				defer wg.Done()
-				parser(doc, &queue, url, &crawled)
-			}(content, url)
+	jobs := make(chan string)
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go worker(ctx, jobs, queue, crawled, robotsEngine, warcWriter, casStore)
+	}
+
+feed:
+	for crawled.Size() < 500 {
+		if queue.Size() == 0 {
+			if inFlight.Load() == 0 {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				break feed
+			case <-time.After(50 * time.Millisecond):
+			}
+			continue
+		}
+		raw, ok := queue.Dequeue()
+		if !ok {
+			continue
+		}
+		if _, _, err := decodeQueueItem(raw); err != nil {
+			printError(err, "Error decoding queue item\t")
+			continue
+		}
+		inFlight.Add(1)
+		select {
+		case jobs <- raw:
+		case <-ctx.Done():
+			// raw was dequeued but never handed to a worker: put it back
+			// so it isn't lost, since nothing has marked it crawled yet.
+			inFlight.Add(-1)
+			if err := queue.Enqueue(raw); err != nil {
+				printError(err, "Error re-enqueueing queue item on shutdown\t")
+			}
+			break feed
 		}
 	}
+	close(jobs)
 
 	wg.Wait()
 
@@ -263,9 +517,9 @@ func main() {
 	done <- true
 
 	fmt.Println("\n------------------CRAWLER STATS------------------")
-	fmt.Printf("Total queued: %d\n", queue.totalQueued)
-	fmt.Printf("To be crawled (Queue) size: %d\n", queue.size())
-	fmt.Printf("Crawled size: %d\n", crawled.size())
+	fmt.Printf("Total queued: %d\n", queue.TotalQueued())
+	fmt.Printf("To be crawled (Queue) size: %d\n", queue.Size())
+	fmt.Printf("Crawled size: %d\n", crawled.Size())
 	crawlerStats.print()
 }
 
@@ -275,9 +529,9 @@ type CrawlerStats struct {
 	crawledRatioPerMinute string
 }
 
-func (c *CrawlerStats) update(crawled *CrawledSet, queue *Queue, t time.Time) {
-	c.pagesPerMinute += fmt.Sprintf("%f %d\n", t.Sub(c.startTime).Minutes(), crawled.size())
-	c.crawledRatioPerMinute += fmt.Sprintf("%f %f\n", t.Sub(c.startTime).Minutes(), float64(crawled.size())/float64(queue.size()))
+func (c *CrawlerStats) update(crawled *store.CrawledSet, queue *store.Queue, t time.Time) {
+	c.pagesPerMinute += fmt.Sprintf("%f %d\n", t.Sub(c.startTime).Minutes(), crawled.Size())
+	c.crawledRatioPerMinute += fmt.Sprintf("%f %f\n", t.Sub(c.startTime).Minutes(), float64(crawled.Size())/float64(queue.Size()))
 }
 
 func (c *CrawlerStats) print() {
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %s", raw, err)
+	}
+	return u
+}
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		seeds []string
+		url   string
+		want  bool
+	}{
+		{"exact seed match", []string{"http://example.com"}, "http://example.com", true},
+		{"subpath of bare-host seed", []string{"http://example.com"}, "http://example.com/a/b", true},
+		{"different host entirely", []string{"http://example.com"}, "http://other.com", false},
+		{"host-prefix escape via suffix domain", []string{"http://example.com"}, "http://example.com.evil.com", false},
+		{"host-prefix escape via sibling domain", []string{"http://example.com"}, "http://example.com-other.com", false},
+		{"subpath of path seed", []string{"http://example.com/blog"}, "http://example.com/blog/post-1", true},
+		{"path-prefix escape via sibling path", []string{"http://example.com/blog"}, "http://example.com/blog-archive", false},
+		{"exact path seed match", []string{"http://example.com/blog"}, "http://example.com/blog", true},
+		{"different scheme", []string{"https://example.com"}, "http://example.com", false},
+		{"case-insensitive host", []string{"http://Example.com"}, "http://example.com/x", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			seeds = nil
+			for _, s := range tc.seeds {
+				seeds = append(seeds, cleanURL(mustParse(t, s)))
+			}
+			got := inScope(cleanURL(mustParse(t, tc.url)))
+			if got != tc.want {
+				t.Errorf("inScope(%q) with seeds %v = %v, want %v", tc.url, tc.seeds, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCleanURL(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"strips fragment", "http://example.com/a#top", "http://example.com/a"},
+		{"lowercases host", "http://Example.COM/a", "http://example.com/a"},
+		{"strips default http port", "http://example.com:80/a", "http://example.com/a"},
+		{"strips default https port", "https://example.com:443/a", "https://example.com/a"},
+		{"keeps non-default port", "http://example.com:8080/a", "http://example.com:8080/a"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := cleanURL(mustParse(t, tc.in)).String()
+			if got != tc.want {
+				t.Errorf("cleanURL(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
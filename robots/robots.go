@@ -0,0 +1,263 @@
+// Package robots is a small robots.txt engine: it fetches and parses
+// robots.txt per scheme+host, applies the longest-match-wins Allow/Disallow
+// semantics, and honors per-host Crawl-delay. Parsed results are cached in
+// a sync.Map and refetched after maxAge so a long-running crawl picks up
+// changes without refetching on every request.
+package robots
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMaxAge = 24 * time.Hour
+
+// defaultUA is the product token Delay uses to pick a Crawl-delay group,
+// since callers generally want "the delay for our own bot" without
+// having to repeat the user agent they passed to Allowed.
+const defaultUA = "Golang_Custom_Bot/1.0"
+
+type rule struct {
+	path  string
+	allow bool
+}
+
+type group struct {
+	agents []string
+	rules  []rule
+	// crawlDelay is NoDelay until a Crawl-delay directive is parsed, so
+	// an explicit "Crawl-delay: 0" can be told apart from no directive.
+	crawlDelay time.Duration
+}
+
+// matches reports whether ua (case-insensitively) is one of this group's
+// product tokens, or the group is the wildcard "*" group.
+func (g group) matches(ua string) bool {
+	for _, agent := range g.agents {
+		if agent == "*" {
+			return true
+		}
+		if strings.EqualFold(agent, ua) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWildcardOnly reports whether g only matches via "*", so a later,
+// more specific group can still take precedence over it.
+func (g group) isWildcardOnly() bool {
+	for _, a := range g.agents {
+		if a != "*" {
+			return false
+		}
+	}
+	return true
+}
+
+type entry struct {
+	fetchedAt time.Time
+	groups    []group
+	sitemaps  []string
+}
+
+// groupFor returns the most specific group applicable to ua: an exact
+// product-token match wins over the "*" wildcard group.
+func (e *entry) groupFor(ua string) *group {
+	var chosen *group
+	for i := range e.groups {
+		g := &e.groups[i]
+		if !g.matches(ua) {
+			continue
+		}
+		if chosen == nil || chosen.isWildcardOnly() {
+			chosen = g
+		}
+	}
+	return chosen
+}
+
+// Robots parses and caches robots.txt files, keyed by "scheme://host".
+type Robots struct {
+	client *http.Client
+	maxAge time.Duration
+	cache  sync.Map // string -> *entry
+}
+
+// New returns a Robots engine that refetches robots.txt every 24h.
+func New() *Robots {
+	return &Robots{
+		client: &http.Client{Timeout: 8 * time.Second},
+		maxAge: defaultMaxAge,
+	}
+}
+
+// Allowed reports whether ua may fetch rawURL according to the target
+// host's robots.txt, fetching and caching it (or reusing a cached, fresh
+// copy) as needed. A robots.txt that fails to fetch is treated as
+// allow-all, per convention.
+func (r *Robots) Allowed(ua, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	e := r.entryFor(u)
+	g := e.groupFor(ua)
+	if g == nil {
+		return true
+	}
+	return allowed(g, u.EscapedPath())
+}
+
+// NoDelay is the sentinel time.Duration Delay returns when a host's
+// robots.txt sets no Crawl-delay, distinguishing "unset" from an
+// explicit "Crawl-delay: 0" (permission to crawl at full speed).
+const NoDelay time.Duration = -1
+
+// Delay returns the Crawl-delay directive for host's robots.txt, or
+// NoDelay if none was set. host may be a bare host or a full URL; only
+// its host (and scheme, if present) are used.
+func (r *Robots) Delay(host string) time.Duration {
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		u, err = url.Parse("https://" + host)
+		if err != nil {
+			return NoDelay
+		}
+	}
+	g := r.entryFor(u).groupFor(defaultUA)
+	if g == nil {
+		return NoDelay
+	}
+	return g.crawlDelay
+}
+
+// Sitemaps returns the Sitemap: directives found in rawURL's host's
+// robots.txt, for seeding the crawl queue.
+func (r *Robots) Sitemaps(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return r.entryFor(u).sitemaps
+}
+
+// allowed applies longest-match-wins: the rule whose path is the longest
+// prefix of path decides; ties favor Allow over Disallow. A Disallow with
+// an empty path is the standard "allow everything" idiom, not a
+// zero-length match against every path, so it is skipped entirely.
+func allowed(g *group, path string) bool {
+	bestLen := -1
+	result := true
+	for _, ru := range g.rules {
+		if ru.path == "" && !ru.allow {
+			continue
+		}
+		if ru.path != "" && !strings.HasPrefix(path, ru.path) {
+			continue
+		}
+		if len(ru.path) > bestLen || (len(ru.path) == bestLen && ru.allow) {
+			bestLen = len(ru.path)
+			result = ru.allow
+		}
+	}
+	return result
+}
+
+func (r *Robots) entryFor(u *url.URL) *entry {
+	key := strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+
+	if v, ok := r.cache.Load(key); ok {
+		if e := v.(*entry); time.Since(e.fetchedAt) < r.maxAge {
+			return e
+		}
+	}
+
+	e := r.fetch(key)
+	r.cache.Store(key, e)
+	return e
+}
+
+func (r *Robots) fetch(base string) *entry {
+	e := &entry{fetchedAt: time.Now()}
+
+	req, err := http.NewRequest("GET", base+"/robots.txt", nil)
+	if err != nil {
+		return e
+	}
+	req.Header.Set("User-Agent", "Golang_Custom_Bot/1.0")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return e
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return e
+	}
+
+	e.groups, e.sitemaps = parse(resp.Body)
+	return e
+}
+
+func parse(body io.Reader) ([]group, []string) {
+	var groups []group
+	var sitemaps []string
+	var current *group
+	sawRule := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		idx := strings.IndexByte(line, ':')
+		if idx < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		val := strings.TrimSpace(line[idx+1:])
+
+		switch key {
+		case "user-agent":
+			if current != nil && sawRule {
+				groups = append(groups, *current)
+				current = nil
+			}
+			if current == nil {
+				current = &group{crawlDelay: NoDelay}
+				sawRule = false
+			}
+			current.agents = append(current.agents, val)
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			current.rules = append(current.rules, rule{path: val, allow: key == "allow"})
+			sawRule = true
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			if secs, err := strconv.ParseFloat(val, 64); err == nil {
+				current.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		case "sitemap":
+			sitemaps = append(sitemaps, val)
+		}
+	}
+	if current != nil {
+		groups = append(groups, *current)
+	}
+	return groups, sitemaps
+}
@@ -0,0 +1,78 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []rule
+		path  string
+		want  bool
+	}{
+		{"no rules allows everything", nil, "/anything", true},
+		{"plain disallow", []rule{{path: "/private", allow: false}}, "/private/page", false},
+		{"plain allow", []rule{{path: "/public", allow: true}}, "/public/page", true},
+		{"unrelated path falls through to allow", []rule{{path: "/private", allow: false}}, "/public", true},
+		{
+			"longest match wins: specific allow over general disallow",
+			[]rule{{path: "/", allow: false}, {path: "/public", allow: true}},
+			"/public/page",
+			true,
+		},
+		{
+			"longest match wins: specific disallow over general allow",
+			[]rule{{path: "/", allow: true}, {path: "/private", allow: false}},
+			"/private/page",
+			false,
+		},
+		{
+			"tie on length favors allow",
+			[]rule{{path: "/x", allow: false}, {path: "/x", allow: true}},
+			"/x",
+			true,
+		},
+		{
+			"empty-path disallow means allow everything",
+			[]rule{{path: "", allow: false}},
+			"/anything",
+			true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &group{rules: tc.rules}
+			if got := allowed(g, tc.path); got != tc.want {
+				t.Errorf("allowed(%v, %q) = %v, want %v", tc.rules, tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGroupFor(t *testing.T) {
+	specific := group{agents: []string{"Golang_Custom_Bot/1.0"}}
+	wildcard := group{agents: []string{"*"}}
+	e := &entry{groups: []group{wildcard, specific}}
+
+	if g := e.groupFor("Golang_Custom_Bot/1.0"); g != &e.groups[1] {
+		t.Errorf("groupFor exact match should prefer the specific group over wildcard")
+	}
+	if g := e.groupFor("SomeOtherBot"); g != &e.groups[0] {
+		t.Errorf("groupFor with no exact match should fall back to the wildcard group")
+	}
+}
+
+func TestParseCrawlDelay(t *testing.T) {
+	groups, _ := parse(strings.NewReader("User-agent: *\nDisallow:\n"))
+	if len(groups) != 1 || groups[0].crawlDelay != NoDelay {
+		t.Fatalf("group with no Crawl-delay directive should keep the NoDelay sentinel, got %+v", groups)
+	}
+
+	groups, _ = parse(strings.NewReader("User-agent: *\nCrawl-delay: 0\n"))
+	if len(groups) != 1 || groups[0].crawlDelay != 0 {
+		t.Errorf("explicit Crawl-delay: 0 should be distinguishable from NoDelay, got %v", groups[0].crawlDelay)
+	}
+}
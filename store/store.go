@@ -0,0 +1,190 @@
+// Package store persists the crawl queue and the set of already-crawled
+// URLs in an embedded bbolt database, so killing the crawler (e.g. with
+// Ctrl-C) and restarting it against the same database resumes the crawl
+// instead of refetching known-good pages.
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	queueBucket   = []byte("queue")
+	crawledBucket = []byte("crawled")
+)
+
+// Store wraps the bbolt database backing a Queue and a CrawledSet.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if necessary) the bbolt database at path and
+// ensures the queue and crawled buckets exist.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("store: open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(queueBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(crawledBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Queue is a FIFO of pending URLs backed by the store's queue bucket.
+// Entries are keyed by a monotonically increasing sequence number so
+// dequeue order survives a restart.
+type Queue struct {
+	store       *Store
+	totalQueued int
+	size        atomic.Int64
+}
+
+// NewQueue returns a Queue over the store's queue bucket, restoring
+// totalQueued and size from any entries already persisted.
+func NewQueue(s *Store) (*Queue, error) {
+	q := &Queue{store: s}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(queueBucket).ForEach(func(k, v []byte) error {
+			q.totalQueued++
+			q.size.Add(1)
+			return nil
+		})
+	})
+	return q, err
+}
+
+// Enqueue appends url to the durable queue.
+func (q *Queue) Enqueue(url string) error {
+	err := q.store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		q.totalQueued++
+		return b.Put(itob(seq), []byte(url))
+	})
+	if err == nil {
+		q.size.Add(1)
+	}
+	return err
+}
+
+// Dequeue removes and returns the oldest queued URL, and reports whether
+// the queue was non-empty.
+func (q *Queue) Dequeue() (string, bool) {
+	var url string
+	var ok bool
+	q.store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		cursor := b.Cursor()
+		k, v := cursor.First()
+		if k == nil {
+			return nil
+		}
+		url = string(v)
+		ok = true
+		return b.Delete(k)
+	})
+	if ok {
+		q.size.Add(-1)
+	}
+	return url, ok
+}
+
+// Size returns the number of URLs currently queued. It's maintained
+// incrementally rather than computed from the bucket's Stats(), which
+// would page-walk the whole bucket on every call.
+func (q *Queue) Size() int {
+	return int(q.size.Load())
+}
+
+// TotalQueued returns the number of URLs enqueued since this Queue was
+// created, plus the entries that were still persisted when it was
+// created. URLs dequeued before a restart are not counted.
+func (q *Queue) TotalQueued() int {
+	return q.totalQueued
+}
+
+// CrawledSet records which URLs have already been fetched, keyed by an
+// FNV-1a hash of the URL.
+type CrawledSet struct {
+	store *Store
+	size  atomic.Int64
+}
+
+// NewCrawledSet returns a CrawledSet over the store's crawled bucket,
+// restoring size from any entries already persisted.
+func NewCrawledSet(s *Store) *CrawledSet {
+	c := &CrawledSet{store: s}
+	s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(crawledBucket).ForEach(func(k, v []byte) error {
+			c.size.Add(1)
+			return nil
+		})
+	})
+	return c
+}
+
+// Add marks url as crawled.
+func (c *CrawledSet) Add(url string) error {
+	key := itob(hashUrl(url))
+	isNew := false
+	err := c.store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(crawledBucket)
+		isNew = b.Get(key) == nil
+		return b.Put(key, []byte(url))
+	})
+	if err == nil && isNew {
+		c.size.Add(1)
+	}
+	return err
+}
+
+// Contains reports whether url has already been crawled.
+func (c *CrawledSet) Contains(url string) bool {
+	var found bool
+	c.store.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(crawledBucket).Get(itob(hashUrl(url))) != nil
+		return nil
+	})
+	return found
+}
+
+// Size returns the number of crawled URLs. It's maintained incrementally
+// rather than computed from the bucket's Stats(), which would page-walk
+// the whole bucket on every call.
+func (c *CrawledSet) Size() int {
+	return int(c.size.Load())
+}
+
+func hashUrl(url string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(url))
+	return h.Sum64()
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "crawler.db"))
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestQueueSizeTracksEnqueueDequeue(t *testing.T) {
+	s := openTestStore(t)
+	q, err := NewQueue(s)
+	if err != nil {
+		t.Fatalf("NewQueue: %s", err)
+	}
+
+	if got := q.Size(); got != 0 {
+		t.Fatalf("Size() on empty queue = %d, want 0", got)
+	}
+
+	q.Enqueue("http://example.com/a")
+	q.Enqueue("http://example.com/b")
+	if got := q.Size(); got != 2 {
+		t.Fatalf("Size() after two enqueues = %d, want 2", got)
+	}
+	if got := q.TotalQueued(); got != 2 {
+		t.Fatalf("TotalQueued() = %d, want 2", got)
+	}
+
+	if _, ok := q.Dequeue(); !ok {
+		t.Fatal("Dequeue() on non-empty queue returned !ok")
+	}
+	if got := q.Size(); got != 1 {
+		t.Fatalf("Size() after one dequeue = %d, want 1", got)
+	}
+	if got := q.TotalQueued(); got != 2 {
+		t.Fatalf("TotalQueued() after dequeue = %d, want unchanged 2", got)
+	}
+}
+
+func TestQueueSizeRestoredOnReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawler.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	q, err := NewQueue(s)
+	if err != nil {
+		t.Fatalf("NewQueue: %s", err)
+	}
+	q.Enqueue("http://example.com/a")
+	q.Enqueue("http://example.com/b")
+	s.Close()
+
+	s2, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %s", err)
+	}
+	defer s2.Close()
+	q2, err := NewQueue(s2)
+	if err != nil {
+		t.Fatalf("NewQueue on reopen: %s", err)
+	}
+	if got := q2.Size(); got != 2 {
+		t.Fatalf("Size() after reopen = %d, want 2", got)
+	}
+}
+
+func TestCrawledSetSizeTracksAddAndDedups(t *testing.T) {
+	s := openTestStore(t)
+	c := NewCrawledSet(s)
+
+	if got := c.Size(); got != 0 {
+		t.Fatalf("Size() on empty set = %d, want 0", got)
+	}
+
+	c.Add("http://example.com/a")
+	c.Add("http://example.com/b")
+	if got := c.Size(); got != 2 {
+		t.Fatalf("Size() after two Adds = %d, want 2", got)
+	}
+
+	// Re-adding an already-crawled URL must not double count.
+	c.Add("http://example.com/a")
+	if got := c.Size(); got != 2 {
+		t.Fatalf("Size() after re-adding a URL = %d, want still 2", got)
+	}
+
+	if !c.Contains("http://example.com/a") {
+		t.Error("Contains() = false for an added URL")
+	}
+	if c.Contains("http://example.com/never-added") {
+		t.Error("Contains() = true for a URL that was never added")
+	}
+}
@@ -0,0 +1,161 @@
+// Package warc writes crawl output as a gzipped WARC/1.1 file.
+//
+// Each fetched page is recorded as a "response" record carrying the raw
+// HTTP response (status line, headers, body) plus a SHA-1 payload digest,
+// and optionally a companion "request" record that points back at it via
+// WARC-Concurrent-To. Records are appended in order by a single writer
+// goroutine-safe Writer; callers from multiple goroutines must still go
+// through the same *Writer so record boundaries never interleave.
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer appends WARC records to a single gzipped file. It is safe for
+// concurrent use; writes are serialized with a mutex because WARC record
+// boundaries must not interleave.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+// NewWriter opens (creating if necessary) the WARC file at path and
+// appends a "warcinfo" record describing the crawler.
+func NewWriter(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("warc: open %s: %w", path, err)
+	}
+	gz := gzip.NewWriter(file)
+	w := &Writer{
+		file: file,
+		gz:   gz,
+		buf:  bufio.NewWriter(gz),
+	}
+	if err := w.writeWarcinfo(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) writeWarcinfo() error {
+	body := []byte("software: Golang_Custom_Bot/1.0\r\nformat: WARC File Format 1.1\r\n")
+	return w.writeRecord(map[string]string{
+		"WARC-Type":      "warcinfo",
+		"WARC-Record-ID": newRecordID(),
+		"WARC-Date":      time.Now().UTC().Format(time.RFC3339),
+		"Content-Type":   "application/warc-fields",
+		"Content-Length": fmt.Sprintf("%d", len(body)),
+	}, body)
+}
+
+// WriteResponse appends a "response" record for targetURI carrying the
+// HTTP status line, headers and body, and returns the record's
+// WARC-Record-ID so a matching WriteRequest can reference it via
+// WARC-Concurrent-To.
+func (w *Writer) WriteResponse(targetURI string, statusLine string, header http.Header, body []byte) (string, error) {
+	recordID := newRecordID()
+
+	httpBuf := []byte(statusLine + "\r\n")
+	httpBuf = append(httpBuf, headerBytes(header)...)
+	httpBuf = append(httpBuf, []byte("\r\n")...)
+	httpBuf = append(httpBuf, body...)
+
+	digest := sha1.Sum(body)
+
+	headers := map[string]string{
+		"WARC-Type":           "response",
+		"WARC-Record-ID":      recordID,
+		"WARC-Target-URI":     targetURI,
+		"WARC-Date":           time.Now().UTC().Format(time.RFC3339),
+		"WARC-Payload-Digest": "sha1:" + hex.EncodeToString(digest[:]),
+		"Content-Type":        "application/http; msgtype=response",
+		"Content-Length":      fmt.Sprintf("%d", len(httpBuf)),
+	}
+	return recordID, w.writeRecord(headers, httpBuf)
+}
+
+// WriteRequest appends a "request" record for targetURI that references
+// an earlier response record via WARC-Concurrent-To.
+func (w *Writer) WriteRequest(targetURI string, concurrentTo string, requestBytes []byte) error {
+	headers := map[string]string{
+		"WARC-Type":          "request",
+		"WARC-Record-ID":     newRecordID(),
+		"WARC-Target-URI":    targetURI,
+		"WARC-Date":          time.Now().UTC().Format(time.RFC3339),
+		"WARC-Concurrent-To": concurrentTo,
+		"Content-Type":       "application/http; msgtype=request",
+		"Content-Length":     fmt.Sprintf("%d", len(requestBytes)),
+	}
+	return w.writeRecord(headers, requestBytes)
+}
+
+// Close flushes and closes the underlying gzip stream and file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *Writer) writeRecord(headers map[string]string, block []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := fmt.Fprint(w.buf, "WARC/1.1\r\n"); err != nil {
+		return err
+	}
+	for _, key := range []string{
+		"WARC-Type", "WARC-Record-ID", "WARC-Date", "WARC-Target-URI",
+		"WARC-Concurrent-To", "WARC-Payload-Digest", "Content-Type", "Content-Length",
+	} {
+		if val, ok := headers[key]; ok {
+			if _, err := fmt.Fprintf(w.buf, "%s: %s\r\n", key, val); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fmt.Fprint(w.buf, "\r\n"); err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(block); err != nil {
+		return err
+	}
+	// WARC records are separated by two CRLFs.
+	_, err := fmt.Fprint(w.buf, "\r\n\r\n")
+	return err
+}
+
+func headerBytes(header http.Header) []byte {
+	var out []byte
+	for key, values := range header {
+		for _, v := range values {
+			out = append(out, []byte(fmt.Sprintf("%s: %s\r\n", key, v))...)
+		}
+	}
+	return out
+}
+
+func newRecordID() string {
+	var raw [16]byte
+	rand.Read(raw[:])
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", raw[0:4], raw[4:6], raw[6:8], raw[8:10], raw[10:16])
+}
@@ -0,0 +1,79 @@
+package warc
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readRecords(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %s", path, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip reader: %s", err)
+	}
+	defer gz.Close()
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	return string(raw)
+}
+
+func TestWriteResponseAndRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.warc.gz")
+	w, err := NewWriter(path)
+	if err != nil {
+		t.Fatalf("NewWriter: %s", err)
+	}
+
+	body := []byte("hello world")
+	header := http.Header{"Content-Type": []string{"text/plain"}}
+	recordID, err := w.WriteResponse("http://example.com/a", "HTTP/1.1 200 OK", header, body)
+	if err != nil {
+		t.Fatalf("WriteResponse: %s", err)
+	}
+	if recordID == "" {
+		t.Fatal("WriteResponse returned empty record ID")
+	}
+
+	if err := w.WriteRequest("http://example.com/a", recordID, []byte("GET /a HTTP/1.1\r\n\r\n")); err != nil {
+		t.Fatalf("WriteRequest: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	out := readRecords(t, path)
+
+	if got := strings.Count(out, "WARC/1.1\r\n"); got != 3 { // warcinfo + response + request
+		t.Errorf("got %d WARC/1.1 record headers, want 3", got)
+	}
+	if !strings.Contains(out, "WARC-Type: response\r\n") {
+		t.Error("missing response record")
+	}
+	if !strings.Contains(out, "WARC-Type: request\r\n") {
+		t.Error("missing request record")
+	}
+	if !strings.Contains(out, "WARC-Target-URI: http://example.com/a\r\n") {
+		t.Error("missing WARC-Target-URI")
+	}
+	if !strings.Contains(out, "WARC-Concurrent-To: "+recordID+"\r\n") {
+		t.Error("request record does not reference the response's record ID")
+	}
+	if !strings.Contains(out, "WARC-Payload-Digest: sha1:") {
+		t.Error("missing payload digest")
+	}
+	if !strings.Contains(out, "hello world") {
+		t.Error("response body not written")
+	}
+}